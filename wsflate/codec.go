@@ -0,0 +1,143 @@
+package wsflate
+
+import (
+	"io"
+	"sync"
+)
+
+// writerPools and readerPools hold throwaway Writer/Reader instances for
+// connections that negotiated no_context_takeover, keyed by window size
+// (index 0 is 8 bits, index 7 is 15 bits) so pooled instances never mix
+// state between differently sized windows. They are intentionally package
+// level: no_context_takeover means a message carries no state from the
+// previous one, so an instance may be reused by any connection sharing the
+// same window size once its current message is done.
+var (
+	writerPools [8]sync.Pool
+	readerPools [8]sync.Pool
+)
+
+func poolIndex(bits WindowBits) int {
+	if !bits.Defined() {
+		// Undefined means the default window size applies.
+		bits = 15
+	}
+	return int(bits) - 8
+}
+
+// MessageCodec is a connection-scoped compressor/decompressor pair aware of
+// the Parameters negotiated for that connection.
+//
+// When the relevant side negotiated context takeover, MessageCodec keeps a
+// single Writer (or Reader) alive for the whole connection and Reset()s it
+// between messages, so LZ77 history carries over from one message to the
+// next — the main performance win of permessage-deflate. When
+// no_context_takeover was negotiated there is no history to carry, so
+// MessageCodec instead borrows a throwaway Writer/Reader from a pool shared
+// by WindowBits. Only the Writer/Reader itself is reused: the Compressor or
+// Decompressor inside it is built anew for every message, so an allocation
+// such as flate.NewWriter still happens once per message.
+//
+// A MessageCodec must not be used concurrently for writing (or for
+// reading) from multiple goroutines, same as Writer and Reader.
+type MessageCodec struct {
+	// Parameters holds the extension parameters negotiated for the
+	// connection this codec serves.
+	Parameters Parameters
+
+	// IsServer reports whether this codec compresses/decompresses on
+	// behalf of the server side of the connection. It picks which half
+	// of Parameters (Server* vs Client*) governs writing and reading.
+	IsServer bool
+
+	// Compressor and Decompressor construct the underlying Compressor
+	// and Decompressor, same meaning as the fields of Helper.
+	Compressor   func(w io.Writer) Compressor
+	Decompressor func(r io.Reader) Decompressor
+
+	writer *Writer
+	reader *Reader
+}
+
+func (c *MessageCodec) writeNoContextTakeover() bool {
+	if c.IsServer {
+		return c.Parameters.ServerNoContextTakeover
+	}
+	return c.Parameters.ClientNoContextTakeover
+}
+
+func (c *MessageCodec) readNoContextTakeover() bool {
+	if c.IsServer {
+		return c.Parameters.ClientNoContextTakeover
+	}
+	return c.Parameters.ServerNoContextTakeover
+}
+
+func (c *MessageCodec) writeWindowBits() WindowBits {
+	if c.IsServer {
+		return c.Parameters.ServerMaxWindowBits
+	}
+	return c.Parameters.ClientMaxWindowBits
+}
+
+func (c *MessageCodec) readWindowBits() WindowBits {
+	if c.IsServer {
+		return c.Parameters.ClientMaxWindowBits
+	}
+	return c.Parameters.ServerMaxWindowBits
+}
+
+// compressor returns a Writer reset to write compressed data into dest,
+// together with a done func that must be called once the caller is
+// finished with it.
+func (c *MessageCodec) compressor(dest io.Writer) (w *Writer, done func()) {
+	if !c.writeNoContextTakeover() {
+		if c.writer == nil {
+			c.writer = &Writer{Compressor: c.Compressor}
+		}
+		c.writer.Reset(dest)
+		return c.writer, func() {}
+	}
+	pool := &writerPools[poolIndex(c.writeWindowBits())]
+	w, _ = pool.Get().(*Writer)
+	if w == nil {
+		w = &Writer{}
+	}
+	// The pool is keyed by WindowBits only, so a Writer pulled from it
+	// may have been built for (and still hold a Compressor instance
+	// from) a different MessageCodec's Compressor func — e.g. another
+	// NewDefaultHelper level or a wholly custom factory. Func values
+	// aren't comparable, so there is no cheap way to tell whether the
+	// cached Compressor still matches c.Compressor; rebind it and force
+	// Writer to build a fresh Compressor instance via w.c = nil rather
+	// than risk silently compressing with someone else's settings.
+	w.Compressor = c.Compressor
+	w.c = nil
+	w.Reset(dest)
+	return w, func() { pool.Put(w) }
+}
+
+// decompressor returns a Reader reset to read compressed data from src,
+// together with a done func that must be called once the caller is
+// finished with it.
+func (c *MessageCodec) decompressor(src io.Reader) (r *Reader, done func()) {
+	if !c.readNoContextTakeover() {
+		if c.reader == nil {
+			c.reader = &Reader{Decompressor: c.Decompressor}
+		}
+		c.reader.Reset(src)
+		return c.reader, func() {}
+	}
+	pool := &readerPools[poolIndex(c.readWindowBits())]
+	r, _ = pool.Get().(*Reader)
+	if r == nil {
+		r = &Reader{}
+	}
+	// Same reasoning as in compressor(): rebind Decompressor and force a
+	// fresh instance rather than risk reusing one built for a different
+	// MessageCodec's Decompressor func.
+	r.Decompressor = c.Decompressor
+	r.d = nil
+	r.Reset(src)
+	return r, func() { pool.Put(r) }
+}
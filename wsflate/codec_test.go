@@ -0,0 +1,121 @@
+package wsflate
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gobwas/ws"
+)
+
+// taggedCompressor is a fake Compressor that ends each Flush with a fixed
+// tag byte followed by the sync-flush tail Writer expects, and implements WriteResetter the same way flate.Writer
+// does (Reset only rebinds the destination, the tag is untouched). It lets
+// the test below tell, byte for byte, whether a pooled Writer's Compressor
+// came from the MessageCodec that asked for it or from whichever other
+// MessageCodec last used that pool slot.
+type taggedCompressor struct {
+	tag  byte
+	dest io.Writer
+}
+
+func newTaggedCompressor(tag byte) func(io.Writer) Compressor {
+	return func(w io.Writer) Compressor {
+		return &taggedCompressor{tag: tag, dest: w}
+	}
+}
+
+func (c *taggedCompressor) Write(p []byte) (int, error) { return c.dest.Write(p) }
+
+func (c *taggedCompressor) Flush() error {
+	_, err := c.dest.Write(append([]byte{c.tag}, compressionTail[:]...))
+	return err
+}
+
+func (c *taggedCompressor) Reset(w io.Writer) { c.dest = w }
+
+func TestMessageCodecPoolDoesNotLeakCompressorAcrossCodecs(t *testing.T) {
+	// Both codecs share the same (undefined, default) WindowBits, so they
+	// draw Writers from the same package-level pool slot.
+	params := Parameters{
+		ServerNoContextTakeover: true,
+		ClientNoContextTakeover: true,
+	}
+	a := &MessageCodec{IsServer: true, Compressor: newTaggedCompressor('A'), Parameters: params}
+	b := &MessageCodec{IsServer: true, Compressor: newTaggedCompressor('B'), Parameters: params}
+
+	tagOf := func(c *MessageCodec) byte {
+		var buf bytes.Buffer
+		w, done := c.compressor(&buf)
+		if _, err := w.Write([]byte("payload")); err != nil {
+			t.Fatalf("Write() unexpected error: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() unexpected error: %v", err)
+		}
+		done()
+		out := buf.Bytes()
+		return out[len(out)-1]
+	}
+
+	// Interleave so that a's Writer is returned to the pool and then
+	// immediately popped back out by b, and vice versa.
+	for i := 0; i < 3; i++ {
+		if got := tagOf(a); got != 'A' {
+			t.Fatalf("round %d: codec a produced tag %q; want 'A' (leaked another codec's Compressor)", i, got)
+		}
+		if got := tagOf(b); got != 'B' {
+			t.Fatalf("round %d: codec b produced tag %q; want 'B' (leaked another codec's Compressor)", i, got)
+		}
+	}
+}
+
+func TestHelperCodecMessageRoundTrip(t *testing.T) {
+	h := Helper{
+		Compressor:   DefaultHelper.Compressor,
+		Decompressor: DefaultHelper.Decompressor,
+		Codec: &MessageCodec{
+			IsServer:     true,
+			Compressor:   DefaultHelper.Compressor,
+			Decompressor: DefaultHelper.Decompressor,
+			Parameters: Parameters{
+				ServerNoContextTakeover: true,
+				ClientNoContextTakeover: true,
+			},
+		},
+	}
+
+	messages := [][]byte{
+		bytes.Repeat([]byte("first message, via the pooled codec path "), 4),
+		bytes.Repeat([]byte("second message, via the pooled codec path "), 4),
+	}
+
+	for i, payload := range messages {
+		in := []ws.Frame{
+			{
+				Header:  ws.FrameHeader{OpCode: ws.OpText},
+				Payload: payload[:len(payload)/2],
+			},
+			{
+				Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpContinuation},
+				Payload: payload[len(payload)/2:],
+			},
+		}
+
+		compressed, err := h.CompressMessage(in)
+		if err != nil {
+			t.Fatalf("message %d: CompressMessage() unexpected error: %v", i, err)
+		}
+
+		out, err := h.DecompressMessage(splitCompressed(compressed))
+		if err != nil {
+			t.Fatalf("message %d: DecompressMessage() unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(out.Payload, payload) {
+			t.Fatalf(
+				"message %d: round-tripped payload mismatch:\ngot:  %q\nwant: %q",
+				i, out.Payload, payload,
+			)
+		}
+	}
+}
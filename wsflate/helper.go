@@ -15,6 +15,7 @@ import (
 // Note that use of DefaultHelper methods assumes that DefaultParameters were
 // used for extension negotiation during WebSocket handshake.
 var DefaultHelper = Helper{
+	Level: 9,
 	Compressor: func(w io.Writer) Compressor {
 		f, _ := flate.NewWriter(w, 9)
 		return f
@@ -24,6 +25,23 @@ var DefaultHelper = Helper{
 	},
 }
 
+// NewDefaultHelper is like DefaultHelper, but its Compressor compresses at
+// the given level (see compress/flate's level constants) instead of the
+// fixed level 9. Lower levels trade compression ratio for CPU time, which
+// matters for latency sensitive payloads where level 9 is overkill.
+func NewDefaultHelper(level int) Helper {
+	return Helper{
+		Level: level,
+		Compressor: func(w io.Writer) Compressor {
+			f, _ := flate.NewWriter(w, level)
+			return f
+		},
+		Decompressor: func(r io.Reader) Decompressor {
+			return flate.NewReader(r)
+		},
+	}
+}
+
 // DefaultParameters holds deflate extension parameters which are assumed by
 // DefaultHelper to be used during WebSocket handshake.
 var DefaultParameters = Parameters{
@@ -71,6 +89,40 @@ func DecompressFrameBuffer(buf Buffer, f ws.Frame) (ws.Frame, error) {
 type Helper struct {
 	Compressor   func(w io.Writer) Compressor
 	Decompressor func(r io.Reader) Decompressor
+
+	// Codec is an opt-in, connection-scoped MessageCodec. When non-nil,
+	// it is used instead of Compressor/Decompressor to obtain the
+	// Writer/Reader backing CompressBuffer/DecompressBuffer, enabling
+	// context takeover and pooling as negotiated by Codec.Parameters.
+	// Leaving it nil preserves the existing per-call behavior.
+	Codec *MessageCodec
+
+	// Level is the compression level used by the default Compressor
+	// built by NewDefaultHelper. It is informational when Compressor is
+	// set explicitly, since Compressor governs the actual level used.
+	Level int
+
+	// DisableWriteCompression, when true, makes CompressFrame,
+	// CompressFrameBuffer, CompressMessage, CompressMessageBuffer,
+	// CompressBuffer and Compress pass payloads through unchanged instead
+	// of compressing them, e.g. to skip compression for payloads that
+	// are already compressed (images, gzip'd JSON, protobufs). It is the
+	// Helper-level equivalent of Writer.EnableWriteCompression; each of
+	// those calls applies it to the whole message it compresses.
+	DisableWriteCompression bool
+
+	// MinSize is the minimum payload size, in bytes, worth compressing.
+	// CompressFrameBuffer leaves frames with a payload smaller than
+	// MinSize untouched (RSV1 cleared, payload unmodified) instead of
+	// round-tripping them through flate: the deflate sync-flush tail
+	// appended to every message can make tiny payloads, like heartbeat
+	// JSON, bigger than they started.
+	//
+	// MinSize has nothing to do with negotiated context takeover (see
+	// MessageCodec) — carrying LZ77 history between messages only
+	// changes how well a message compresses, not whether it is worth
+	// compressing at all.
+	MinSize int
 }
 
 // Buffer is an interface representing some bytes buffering object.
@@ -97,10 +149,20 @@ func (h *Helper) DecompressFrame(in ws.Frame) (f ws.Frame, err error) {
 
 // CompressFrameBuffer compresses a frame using given buffer.
 // Returned frame's payload holds bytes returned by buf.Bytes().
+//
+// CompressFrameBuffer only accepts unfragmented (Header.Fin == true)
+// frames. To compress a message that is split across several frames use
+// CompressMessageBuffer instead.
 func (h *Helper) CompressFrameBuffer(buf Buffer, in ws.Frame) (f ws.Frame, err error) {
 	if !in.Header.Fin {
 		return f, fmt.Errorf("wsflate: fragmented messages are not allowed")
 	}
+	if h.DisableWriteCompression || len(in.Payload) < h.MinSize {
+		f = in
+		_, r2, r3 := ws.RsvBits(f.Header.Rsv)
+		f.Header.Rsv = ws.Rsv(false, r2, r3)
+		return f, nil
+	}
 	p, err := h.CompressBuffer(buf, in.Payload)
 	if err != nil {
 		return f, err
@@ -109,7 +171,7 @@ func (h *Helper) CompressFrameBuffer(buf Buffer, in ws.Frame) (f ws.Frame, err e
 	f = in
 	f.Payload = p
 	f.Header.Length = int64(len(p))
-	f.Header.Rsv, err = BitsSend(0, f.Header.Rsv)
+	f.Header.Rsv, err = ExtendWrite(0, f.Header.Rsv)
 	if err != nil {
 		return f, err
 	}
@@ -118,6 +180,10 @@ func (h *Helper) CompressFrameBuffer(buf Buffer, in ws.Frame) (f ws.Frame, err e
 
 // DecompressFrameBuffer decompresses a frame using given buffer.
 // Returned frame's payload holds bytes returned by buf.Bytes().
+//
+// DecompressFrameBuffer only accepts unfragmented (Header.Fin == true)
+// frames. To decompress a message that is split across several frames use
+// DecompressMessageBuffer instead.
 func (h *Helper) DecompressFrameBuffer(buf Buffer, in ws.Frame) (f ws.Frame, err error) {
 	if !in.Header.Fin {
 		return f, fmt.Errorf("wsflate: fragmented messages are not allowed")
@@ -130,7 +196,107 @@ func (h *Helper) DecompressFrameBuffer(buf Buffer, in ws.Frame) (f ws.Frame, err
 	f = in
 	f.Payload = p
 	f.Header.Length = int64(len(p))
-	f.Header.Rsv, err = BitsRecv(0, f.Header.Rsv)
+	f.Header.Rsv, err = ExtendRead(0, f.Header.Rsv)
+	if err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// CompressMessage compresses a complete message given as a sequence of its
+// fragment frames fr, feeding every fragment's payload through a single
+// Writer instance so that LZ77 back-references may span fragment
+// boundaries, and returns a single, unfragmented compressed frame.
+//
+// Frames in fr are expected to belong to one message, in order, the way
+// they would arrive off the wire (Header.Fin set only on the last one).
+// Note that it does memory allocations internally. To control those
+// allocations consider using CompressMessageBuffer().
+func (h *Helper) CompressMessage(fr []ws.Frame) (f ws.Frame, err error) {
+	var buf bytes.Buffer
+	return h.CompressMessageBuffer(&buf, fr)
+}
+
+// CompressMessageBuffer compresses a message given as a sequence of its
+// fragment frames fr using given buffer. Returned frame's payload holds
+// bytes returned by buf.Bytes().
+//
+// See CompressMessage for details.
+func (h *Helper) CompressMessageBuffer(buf Buffer, fr []ws.Frame) (f ws.Frame, err error) {
+	if len(fr) == 0 {
+		return f, fmt.Errorf("wsflate: empty message")
+	}
+	w, done := h.writer(buf)
+	defer done()
+	for _, in := range fr {
+		if _, err = w.Write(in.Payload); err != nil {
+			return f, err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return f, err
+	}
+	if err = w.Close(); err != nil {
+		return f, err
+	}
+	// Copy header of the first fragment: it carries the opcode of the
+	// message and, per RFC 7692, is the only frame allowed to have RSV1
+	// set.
+	f = fr[0]
+	f.Payload = buf.Bytes()
+	f.Header.Length = int64(len(f.Payload))
+	f.Header.Fin = true
+	if !h.DisableWriteCompression {
+		f.Header.Rsv, err = ExtendWrite(0, f.Header.Rsv)
+		if err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// DecompressMessage decompresses a complete compressed message given as a
+// sequence of its fragment frames fr, feeding every fragment's payload
+// through a single Reader instance and emitting the trailing
+// 00 00 ff ff tail handling only once, for the final fragment. It returns
+// a single, unfragmented decompressed frame.
+//
+// Note that it does memory allocations internally. To control those
+// allocations consider using DecompressMessageBuffer().
+func (h *Helper) DecompressMessage(fr []ws.Frame) (f ws.Frame, err error) {
+	var buf bytes.Buffer
+	return h.DecompressMessageBuffer(&buf, fr)
+}
+
+// DecompressMessageBuffer decompresses a message given as a sequence of its
+// fragment frames fr using given buffer. Returned frame's payload holds
+// bytes returned by buf.Bytes().
+//
+// See DecompressMessage for details.
+func (h *Helper) DecompressMessageBuffer(buf Buffer, fr []ws.Frame) (f ws.Frame, err error) {
+	if len(fr) == 0 {
+		return f, fmt.Errorf("wsflate: empty message")
+	}
+	parts := make([]io.Reader, len(fr))
+	for i, in := range fr {
+		if _, err = ExtendRead(i, in.Header.Rsv); err != nil {
+			return f, err
+		}
+		parts[i] = bytes.NewReader(in.Payload)
+	}
+	r, done := h.reader(io.MultiReader(parts...))
+	defer done()
+	if _, err = io.Copy(buf, r); err != nil {
+		return f, err
+	}
+	if err = r.Close(); err != nil {
+		return f, err
+	}
+	f = fr[0]
+	f.Payload = buf.Bytes()
+	f.Header.Length = int64(len(f.Payload))
+	f.Header.Fin = true
+	f.Header.Rsv, err = ExtendRead(0, f.Header.Rsv)
 	if err != nil {
 		return f, err
 	}
@@ -156,7 +322,8 @@ func (h *Helper) Decompress(p []byte) ([]byte, error) {
 // CompressBuffer compresses bytes using given buffer.
 // Returned bytes are bytes returned by buf.Bytes().
 func (h *Helper) CompressBuffer(buf Buffer, p []byte) (_ []byte, err error) {
-	w := NewWriter(buf, h.Compressor)
+	w, done := h.writer(buf)
+	defer done()
 	if _, err = w.Write(p); err != nil {
 		return nil, err
 	}
@@ -172,7 +339,8 @@ func (h *Helper) CompressBuffer(buf Buffer, p []byte) (_ []byte, err error) {
 // DecompressBuffer decompresses bytes using given buffer.
 // Returned bytes are bytes returned by buf.Bytes().
 func (h *Helper) DecompressBuffer(buf Buffer, p []byte) (_ []byte, err error) {
-	fr := NewReader(bytes.NewReader(p), h.Decompressor)
+	fr, done := h.reader(bytes.NewReader(p))
+	defer done()
 	if _, err = io.Copy(buf, fr); err != nil {
 		return nil, err
 	}
@@ -181,3 +349,26 @@ func (h *Helper) DecompressBuffer(buf Buffer, p []byte) (_ []byte, err error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// writer returns the Writer to use for compressing into dest: Codec's
+// pooled/retained Writer when Codec is set, or a fresh one-off Writer
+// otherwise.
+func (h *Helper) writer(dest io.Writer) (w *Writer, done func()) {
+	if h.Codec != nil {
+		w, done = h.Codec.compressor(dest)
+	} else {
+		w, done = NewWriter(dest, h.Compressor), func() {}
+	}
+	w.EnableWriteCompression(!h.DisableWriteCompression)
+	return w, done
+}
+
+// reader returns the Reader to use for decompressing from src: Codec's
+// pooled/retained Reader when Codec is set, or a fresh one-off Reader
+// otherwise.
+func (h *Helper) reader(src io.Reader) (r *Reader, done func()) {
+	if h.Codec != nil {
+		return h.Codec.decompressor(src)
+	}
+	return NewReader(src, h.Decompressor), func() {}
+}
@@ -0,0 +1,63 @@
+package wsflate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gobwas/ws"
+)
+
+func TestHelperMinSizeBypassesCompression(t *testing.T) {
+	h := Helper{
+		Compressor:   DefaultHelper.Compressor,
+		Decompressor: DefaultHelper.Decompressor,
+		MinSize:      16,
+	}
+
+	small := []byte("ping")
+	f, err := h.CompressFrame(ws.Frame{
+		Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpText},
+		Payload: small,
+	})
+	if err != nil {
+		t.Fatalf("CompressFrame() unexpected error: %v", err)
+	}
+	if !bytes.Equal(f.Payload, small) {
+		t.Fatalf("payload below MinSize was modified: got %q, want %q", f.Payload, small)
+	}
+	if r1, _, _ := ws.RsvBits(f.Header.Rsv); r1 {
+		t.Fatalf("RSV1 set on a frame left uncompressed by MinSize")
+	}
+
+	large := bytes.Repeat([]byte("x"), 64)
+	f, err = h.CompressFrame(ws.Frame{
+		Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpText},
+		Payload: large,
+	})
+	if err != nil {
+		t.Fatalf("CompressFrame() unexpected error: %v", err)
+	}
+	if r1, _, _ := ws.RsvBits(f.Header.Rsv); !r1 {
+		t.Fatalf("RSV1 not set on a frame at/above MinSize")
+	}
+}
+
+func TestNewDefaultHelperRoundTrip(t *testing.T) {
+	h := NewDefaultHelper(1)
+	if h.Level != 1 {
+		t.Fatalf("Level = %d; want 1", h.Level)
+	}
+
+	payload := bytes.Repeat([]byte("hello, world "), 8)
+	p, err := h.Compress(payload)
+	if err != nil {
+		t.Fatalf("Compress() unexpected error: %v", err)
+	}
+	out, err := h.Decompress(p)
+	if err != nil {
+		t.Fatalf("Decompress() unexpected error: %v", err)
+	}
+	if !bytes.Equal(out, payload) {
+		t.Fatalf("round-tripped payload mismatch:\ngot:  %q\nwant: %q", out, payload)
+	}
+}
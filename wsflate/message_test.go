@@ -0,0 +1,81 @@
+package wsflate
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gobwas/ws"
+)
+
+// splitCompressed re-wraps a single compressed frame as two physical wire
+// frames, the way a peer that fragments compressed messages would send it:
+// RSV1 (and the opcode) only on the first fragment, FIN only on the last.
+func splitCompressed(f ws.Frame) []ws.Frame {
+	mid := len(f.Payload) / 2
+	return []ws.Frame{
+		{
+			Header: ws.FrameHeader{
+				OpCode: f.Header.OpCode,
+				Rsv:    f.Header.Rsv,
+			},
+			Payload: f.Payload[:mid],
+		},
+		{
+			Header: ws.FrameHeader{
+				Fin:    true,
+				OpCode: ws.OpContinuation,
+			},
+			Payload: f.Payload[mid:],
+		},
+	}
+}
+
+func TestHelperMessageRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog "), 4)
+
+	in := []ws.Frame{
+		{
+			Header:  ws.FrameHeader{OpCode: ws.OpText},
+			Payload: payload[:len(payload)/2],
+		},
+		{
+			Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpContinuation},
+			Payload: payload[len(payload)/2:],
+		},
+	}
+
+	compressed, err := DefaultHelper.CompressMessage(in)
+	if err != nil {
+		t.Fatalf("CompressMessage() unexpected error: %v", err)
+	}
+
+	out, err := DefaultHelper.DecompressMessage(splitCompressed(compressed))
+	if err != nil {
+		t.Fatalf("DecompressMessage() unexpected error: %v", err)
+	}
+	if !bytes.Equal(out.Payload, payload) {
+		t.Fatalf("round-tripped payload mismatch:\ngot:  %q\nwant: %q", out.Payload, payload)
+	}
+}
+
+func TestHelperDecompressMessageRejectsRsv1OnContinuation(t *testing.T) {
+	payload := bytes.Repeat([]byte("payload"), 8)
+	compressed, err := DefaultHelper.CompressFrame(ws.Frame{
+		Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpBinary},
+		Payload: payload,
+	})
+	if err != nil {
+		t.Fatalf("CompressFrame() unexpected error: %v", err)
+	}
+
+	fr := splitCompressed(compressed)
+	// Illegally set RSV1 on the (non-first) continuation fragment.
+	_, r2, r3 := ws.RsvBits(fr[1].Header.Rsv)
+	fr[1].Header.Rsv = ws.Rsv(true, r2, r3)
+
+	_, err = DefaultHelper.DecompressMessage(fr)
+	if !errors.Is(err, errNonFirstFragmentEnabledBit) {
+		t.Fatalf("DecompressMessage() error = %v; want %v", err, errNonFirstFragmentEnabledBit)
+	}
+}
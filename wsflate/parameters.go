@@ -215,30 +215,39 @@ type Extension struct {
 //
 // It may return zero option (i.e. one which Name field is nil) alongside with
 // nil error.
+//
+// Negotiate may be called multiple times during a single upgrade, once per
+// offer listed in the Sec-WebSocket-Extensions header. Per RFC 7692, a
+// client lists its permessage-deflate offers in preference order, so once
+// an offer is accepted, further offers are ignored. An offer that is
+// unacceptable (e.g. asks for a too-large server_max_window_bits) is
+// declined without error, leaving n free to accept a later, less
+// preferred offer in the same header.
 func (n *Extension) Negotiate(opt httphead.Option) (accept httphead.Option, err error) {
 	if !bytes.Equal(opt.Name, ExtensionNameBytes) {
 		return
 	}
 	if n.accepted {
-		// Negotiate might be called multiple times during upgrade.
-		// We stick to first one accepted extension since they must be passed
-		// in ordered by preference.
+		// An extension was already accepted earlier in this upgrade;
+		// since offers are listed in preference order there is
+		// nothing left to improve on.
 		return
 	}
 
 	want := n.Parameters
 
-	if err = n.params.Parse(opt); err != nil {
+	var params Parameters
+	if err = params.Parse(opt); err != nil {
 		return
 	}
 	{
-		offer := n.params.ServerMaxWindowBits
+		offer := params.ServerMaxWindowBits
 		want := want.ServerMaxWindowBits
 		if offer > want {
 			// A server declines an extension negotiation offer
 			// with this parameter if the server doesn't support
 			// it.
-			return
+			return httphead.Option{}, nil
 		}
 	}
 	{
@@ -246,21 +255,22 @@ func (n *Extension) Negotiate(opt httphead.Option) (accept httphead.Option, err
 		// "client_max_window_bits" extension parameter, the server MAY
 		// include the "client_max_window_bits" extension parameter in the
 		// corresponding extension negotiation response to the offer.
-		offer := n.params.ClientMaxWindowBits
+		offer := params.ClientMaxWindowBits
 		want := want.ClientMaxWindowBits
 		if want > offer {
-			return
+			return httphead.Option{}, nil
 		}
 	}
 	{
-		offer := n.params.ServerNoContextTakeover
+		offer := params.ServerNoContextTakeover
 		want := want.ServerNoContextTakeover
 		if offer && !want {
-			return
+			return httphead.Option{}, nil
 		}
 	}
 
 	n.accepted = true
+	n.params = params
 
 	return want.Option(), nil
 }
@@ -0,0 +1,50 @@
+package wsflate
+
+import (
+	"testing"
+
+	"github.com/gobwas/httphead"
+)
+
+func TestExtensionNegotiateSkipsUnacceptableOffers(t *testing.T) {
+	header := []byte(
+		`permessage-deflate; server_max_window_bits=15, ` +
+			`permessage-deflate; server_max_window_bits=10`,
+	)
+	opts, ok := httphead.ParseOptions(header, nil)
+	if !ok {
+		t.Fatalf("httphead.ParseOptions() failed to parse header: %q", header)
+	}
+	if n := len(opts); n != 2 {
+		t.Fatalf("got %d options; want 2", n)
+	}
+
+	e := Extension{
+		Parameters: Parameters{
+			ServerMaxWindowBits: 10,
+		},
+	}
+
+	var accept httphead.Option
+	for _, opt := range opts {
+		var err error
+		accept, err = e.Negotiate(opt)
+		if err != nil {
+			t.Fatalf("Negotiate(%v) unexpected error: %v", opt, err)
+		}
+	}
+
+	params, accepted := e.Accepted()
+	if !accepted {
+		t.Fatalf("expected second offer to be accepted")
+	}
+	if params.ServerMaxWindowBits != 10 {
+		t.Fatalf(
+			"unexpected accepted ServerMaxWindowBits: %d; want 10",
+			params.ServerMaxWindowBits,
+		)
+	}
+	if accept.Name == nil {
+		t.Fatalf("expected non-zero accept option")
+	}
+}
@@ -0,0 +1,97 @@
+package wsflate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/gobwas/ws"
+)
+
+// PreparedMessage holds a payload compressed once against a given
+// Parameters set, so it can be written to many connections negotiating
+// matching parameters without compressing it again for each one. This is
+// useful for fan-out servers (pub/sub, game tick broadcasts) where the same
+// payload goes out to N clients and compressing it N times would dominate
+// CPU.
+type PreparedMessage struct {
+	// Parameters are the Parameters the payload was compressed against.
+	Parameters Parameters
+
+	frame    ws.Frame
+	reusable bool
+	written  int32
+}
+
+// Prepare is a shortcut for DefaultHelper.Prepare().
+func Prepare(payload []byte) (*PreparedMessage, error) {
+	return DefaultHelper.Prepare(payload)
+}
+
+// Prepare compresses payload once, using h's Compressor, and returns a
+// PreparedMessage ready to be written, via WriteTo, to connections that
+// negotiated h.Codec.Parameters (or DefaultParameters, if h.Codec is nil).
+//
+// When the negotiated parameters have no_context_takeover in effect for
+// writes, the compressed bytes are self-contained and WriteTo may be
+// called any number of times, including on different connections. When
+// context takeover is in effect, a peer's decompressor dictionary depends
+// on every message sent to it before this one, so reusing the same
+// compressed bytes for an unrelated message would desynchronize that
+// state; WriteTo refuses to send such a PreparedMessage more than once.
+func (h *Helper) Prepare(payload []byte) (*PreparedMessage, error) {
+	var buf bytes.Buffer
+	p, err := h.CompressBuffer(&buf, payload)
+	if err != nil {
+		return nil, err
+	}
+	f := ws.NewFrame(ws.OpBinary, true, p)
+	if !h.DisableWriteCompression {
+		f.Header.Rsv, err = ExtendWrite(0, f.Header.Rsv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params, reusable := DefaultParameters, true
+	if h.Codec != nil {
+		params = h.Codec.Parameters
+		reusable = h.Codec.writeNoContextTakeover()
+	}
+
+	return &PreparedMessage{
+		Parameters: params,
+		frame:      f,
+		reusable:   reusable,
+	}, nil
+}
+
+// Frame returns the prepared, compressed frame. Callers must not mutate its
+// Payload, since it is shared by every WriteTo call.
+func (m *PreparedMessage) Frame() ws.Frame {
+	return m.frame
+}
+
+// WriteTo writes the prepared frame to w, masking it first if mask is true
+// (as required for frames sent from a client to a server).
+//
+// It returns an error without writing anything if m.Parameters does not
+// allow reuse (see Prepare) and this is not the first call to WriteTo.
+func (m *PreparedMessage) WriteTo(w io.Writer, mask bool) error {
+	if !m.reusable && !atomic.CompareAndSwapInt32(&m.written, 0, 1) {
+		return fmt.Errorf(
+			"wsflate: PreparedMessage was already written and its " +
+				"Parameters do not allow reuse",
+		)
+	}
+	f := m.frame
+	if mask {
+		// MaskFrame masks f.Payload in place; copy it first so that
+		// masking this write doesn't corrupt the shared, cached bytes
+		// read by concurrent or later WriteTo calls.
+		f.Payload = append([]byte(nil), f.Payload...)
+		f = ws.MaskFrame(f)
+	}
+	return ws.WriteFrame(w, f)
+}
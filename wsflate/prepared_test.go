@@ -0,0 +1,85 @@
+package wsflate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gobwas/ws"
+)
+
+func TestHelperPrepareDisableWriteCompression(t *testing.T) {
+	h := Helper{
+		Compressor:              DefaultHelper.Compressor,
+		Decompressor:            DefaultHelper.Decompressor,
+		DisableWriteCompression: true,
+	}
+
+	payload := bytes.Repeat([]byte("already compressed elsewhere "), 8)
+
+	m, err := h.Prepare(payload)
+	if err != nil {
+		t.Fatalf("Prepare() unexpected error: %v", err)
+	}
+
+	f := m.Frame()
+	if !bytes.Equal(f.Payload, payload) {
+		t.Fatalf("payload was compressed despite DisableWriteCompression")
+	}
+	if r1, _, _ := ws.RsvBits(f.Header.Rsv); r1 {
+		t.Fatalf("RSV1 set despite DisableWriteCompression")
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteTo(&buf, false); err != nil {
+		t.Fatalf("WriteTo() unexpected error: %v", err)
+	}
+	fr, err := ws.ReadFrame(&buf)
+	if err != nil {
+		t.Fatalf("ws.ReadFrame() unexpected error: %v", err)
+	}
+	if r1, _, _ := ws.RsvBits(fr.Header.Rsv); r1 {
+		t.Fatalf("RSV1 set on wire frame despite DisableWriteCompression")
+	}
+	if !bytes.Equal(fr.Payload, payload) {
+		t.Fatalf("wire payload mismatch:\ngot:  %q\nwant: %q", fr.Payload, payload)
+	}
+}
+
+func TestPreparedMessageWriteToReusable(t *testing.T) {
+	payload := bytes.Repeat([]byte("prepared message payload "), 6)
+
+	m, err := DefaultHelper.Prepare(payload)
+	if err != nil {
+		t.Fatalf("Prepare() unexpected error: %v", err)
+	}
+	if !m.reusable {
+		t.Fatalf("expected PreparedMessage to be reusable under DefaultParameters")
+	}
+
+	// Write the same PreparedMessage, masked, more than once: masking
+	// must not corrupt the cached, shared payload bytes for later calls.
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := m.WriteTo(&buf, true); err != nil {
+			t.Fatalf("write %d: WriteTo() unexpected error: %v", i, err)
+		}
+
+		fr, err := ws.ReadFrame(&buf)
+		if err != nil {
+			t.Fatalf("write %d: ws.ReadFrame() unexpected error: %v", i, err)
+		}
+		// The frame was written masked; unmask it before decompressing.
+		ws.Cipher(fr.Payload, fr.Header.Mask, 0)
+
+		out, err := DefaultHelper.DecompressFrame(fr)
+		if err != nil {
+			t.Fatalf("write %d: DecompressFrame() unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(out.Payload, payload) {
+			t.Fatalf(
+				"write %d: round-tripped payload mismatch:\ngot:  %q\nwant: %q",
+				i, out.Payload, payload,
+			)
+		}
+	}
+}
@@ -43,9 +43,9 @@ func (r *Reader) init() {
 
 // Reset resets Reader to decompress data from src.
 func (r *Reader) Reset(src io.Reader) {
+	r.Source = src
 	r.init()
 	r.err = nil
-	r.Source = src
 	r.sr.reset(src)
 	if rr, _ := r.d.(ReadResetter); rr != nil {
 		rr.Reset(&r.sr)
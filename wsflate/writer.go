@@ -45,6 +45,27 @@ type Writer struct {
 	c    Compressor
 	err  error
 	cbuf cbuf
+
+	disableCompression bool
+}
+
+// EnableWriteCompression controls whether Write/Flush/Close compress data
+// written for the current message or pass it through to Dest unchanged.
+// Compression is enabled by default.
+//
+// When disabled, Write copies bytes straight to Dest, and Flush/Close skip
+// both the Compressor and the trailing-tail check normally performed by
+// checkTail, so Dest ends up holding exactly what was written — e.g. to
+// skip compression for payloads that are already compressed (images,
+// gzip'd JSON, protobufs) while still compressing others on the same
+// Writer.
+//
+// The setting takes effect immediately, for bytes written after the call;
+// it is not deferred to the next message boundary. Toggling it in the
+// middle of a message interleaves compressed and uncompressed bytes in
+// Dest and corrupts the stream.
+func (w *Writer) EnableWriteCompression(enable bool) {
+	w.disableCompression = !enable
 }
 
 func (w *Writer) init() {
@@ -57,6 +78,7 @@ func (w *Writer) init() {
 // Reset resets Writer to compress data into dest.
 // Any not flushed data will be lost.
 func (w *Writer) Reset(dest io.Writer) {
+	w.Dest = dest
 	w.init()
 	w.err = nil
 	w.cbuf.reset(dest)
@@ -69,6 +91,12 @@ func (w *Writer) Reset(dest io.Writer) {
 
 // Write implements io.Writer.
 func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.disableCompression {
+		if w.err != nil {
+			return 0, w.err
+		}
+		return w.Dest.Write(p)
+	}
 	w.init()
 	if w.err != nil {
 		return 0, w.err
@@ -79,6 +107,9 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 
 // Flush writes any pending data into w.Dest.
 func (w *Writer) Flush() error {
+	if w.disableCompression {
+		return w.err
+	}
 	w.init()
 	if w.err != nil {
 		return w.err
@@ -94,6 +125,9 @@ func (w *Writer) Close() error {
 	if w.err != nil {
 		return w.err
 	}
+	if w.disableCompression {
+		return nil
+	}
 	w.init()
 	if c, _ := w.c.(io.Closer); c != nil {
 		w.err = c.Close()
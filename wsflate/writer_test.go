@@ -0,0 +1,67 @@
+package wsflate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gobwas/ws"
+)
+
+func TestHelperDisableWriteCompression(t *testing.T) {
+	h := Helper{
+		Compressor:              DefaultHelper.Compressor,
+		Decompressor:            DefaultHelper.Decompressor,
+		DisableWriteCompression: true,
+	}
+
+	payload := bytes.Repeat([]byte("already compressed elsewhere "), 8)
+
+	f, err := h.CompressFrame(ws.Frame{
+		Header:  ws.FrameHeader{Fin: true, OpCode: ws.OpBinary},
+		Payload: payload,
+	})
+	if err != nil {
+		t.Fatalf("CompressFrame() unexpected error: %v", err)
+	}
+	if !bytes.Equal(f.Payload, payload) {
+		t.Fatalf("payload was compressed despite DisableWriteCompression")
+	}
+	if r1, _, _ := ws.RsvBits(f.Header.Rsv); r1 {
+		t.Fatalf("RSV1 set despite DisableWriteCompression")
+	}
+
+	in := []ws.Frame{
+		{Header: ws.FrameHeader{OpCode: ws.OpBinary}, Payload: payload[:len(payload)/2]},
+		{Header: ws.FrameHeader{Fin: true, OpCode: ws.OpContinuation}, Payload: payload[len(payload)/2:]},
+	}
+	msg, err := h.CompressMessage(in)
+	if err != nil {
+		t.Fatalf("CompressMessage() unexpected error: %v", err)
+	}
+	if !bytes.Equal(msg.Payload, payload) {
+		t.Fatalf("message payload was compressed despite DisableWriteCompression")
+	}
+	if r1, _, _ := ws.RsvBits(msg.Header.Rsv); r1 {
+		t.Fatalf("RSV1 set on message despite DisableWriteCompression")
+	}
+}
+
+func TestWriterEnableWriteCompressionFalsePassesThrough(t *testing.T) {
+	var dest bytes.Buffer
+	w := &Writer{Dest: &dest, Compressor: DefaultHelper.Compressor}
+	w.EnableWriteCompression(false)
+
+	payload := []byte("pass me through untouched")
+	if _, err := w.Write(payload); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	if !bytes.Equal(dest.Bytes(), payload) {
+		t.Fatalf("Dest = %q; want untouched %q", dest.Bytes(), payload)
+	}
+}